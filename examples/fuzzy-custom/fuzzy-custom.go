@@ -116,12 +116,17 @@ func (s Workflows) Filter(query string, max int) Workflows {
 	return hits
 }
 
-// loadWorkflows loads the list of workflows from the workflow's directory.
+// loadWorkflows loads the list of workflows from the workflow's directory,
+// via wf.CachedData() with a 24-hour maxAge, so runs within that window
+// read the cached copy instead of workflows.json itself. The JSON is
+// still unmarshalled on every run.
 func loadWorkflows() (Workflows, error) {
 	start := time.Now()
 	path := filepath.Join(wf.Dir(), workflowJSON)
-	// Unmarshal workflows.json
-	data, err := ioutil.ReadFile(path)
+
+	data, err := wf.CachedData(workflowJSON, func() ([]byte, error) {
+		return ioutil.ReadFile(path)
+	}, 24*time.Hour)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,350 @@
+//
+// Copyright (c) 2017 Dean Jackson <deanishe@deanishe.net>
+//
+// MIT Licence. See http://opensource.org/licenses/MIT
+//
+// Created on 2017-09-09
+//
+
+/*
+Package update implements a self-updater for Alfred workflows.
+
+It checks a GitHub repo's Releases for a newer `.alfredworkflow` file,
+caching the result of the check so repeated calls are cheap, and can
+install the update by downloading the asset and handing it to Alfred
+via `open`.
+*/
+package update
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultFrequency is how often CheckForUpdate will hit the GitHub API
+// if no other Frequency is set.
+const defaultFrequency = 24 * time.Hour
+
+// cacheFilename is the name of the file the check result is cached under,
+// relative to Updater.CacheDir.
+const cacheFilename = "_aw_update_check.json"
+
+var versionPattern = regexp.MustCompile(`\d+(\.\d+)*`)
+
+// Updater checks for and installs newer versions of a workflow by
+// comparing its current version against the releases of a GitHub repo.
+type Updater struct {
+	// Repo is the GitHub repo slug, e.g. "deanishe/alfred-ssh".
+	Repo string
+
+	// CurrentVersion is the currently-installed version of the workflow.
+	CurrentVersion string
+
+	// Prereleases, if true, allows pre-release tags to be considered
+	// when looking for the latest version.
+	Prereleases bool
+
+	// Frequency is how often to actually query GitHub for a new
+	// version. Between checks, the cached result is returned.
+	// Defaults to 24 hours.
+	Frequency time.Duration
+
+	// CacheDir is the directory the check result and downloaded
+	// `.alfredworkflow` file are cached in. This should usually be
+	// set to Workflow.GetCacheDir().
+	CacheDir string
+
+	client *http.Client
+}
+
+// New creates a new Updater for the given GitHub repo slug and the
+// workflow's current version.
+func New(repo, currentVersion string) *Updater {
+	return &Updater{
+		Repo:           repo,
+		CurrentVersion: currentVersion,
+		Frequency:      defaultFrequency,
+		client:         http.DefaultClient,
+	}
+}
+
+// release is the subset of the GitHub releases API response that
+// Updater cares about.
+type release struct {
+	TagName    string  `json:"tag_name"`
+	Prerelease bool    `json:"prerelease"`
+	Assets     []asset `json:"assets"`
+}
+
+// asset is a single file attached to a GitHub release.
+type asset struct {
+	Name               string `json:"name"`
+	BrowserDownloadURL string `json:"browser_download_url"`
+}
+
+// workflowAsset returns the release's `.alfredworkflow` asset, if any.
+func (r release) workflowAsset() (asset, bool) {
+	for _, a := range r.Assets {
+		if strings.HasSuffix(a.Name, ".alfredworkflow") {
+			return a, true
+		}
+	}
+	return asset{}, false
+}
+
+// result is the outcome of a check, persisted as JSON in CacheDir so
+// repeated calls to CheckForUpdate don't have to hit the network.
+type result struct {
+	Available   bool      `json:"available"`
+	Version     string    `json:"version"`
+	DownloadURL string    `json:"download_url"`
+	AssetName   string    `json:"asset_name"`
+	CheckedAt   time.Time `json:"checked_at"`
+}
+
+// cachePath returns the path to the cached check result.
+func (u *Updater) cachePath() string {
+	return filepath.Join(u.CacheDir, cacheFilename)
+}
+
+// cached reads the cached check result, if any.
+func (u *Updater) cached() (*result, error) {
+	data, err := ioutil.ReadFile(u.cachePath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var r result
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// cache persists a check result so future calls can skip the network.
+func (u *Updater) cache(r *result) error {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(u.cachePath(), data, 0600)
+}
+
+// frequency returns u.Frequency, falling back to defaultFrequency.
+func (u *Updater) frequency() time.Duration {
+	if u.Frequency <= 0 {
+		return defaultFrequency
+	}
+	return u.Frequency
+}
+
+// CheckForUpdate returns true if a newer version of the workflow is
+// available. The GitHub API is only actually queried once every
+// u.frequency(); between checks, the cached verdict is returned.
+func (u *Updater) CheckForUpdate() (bool, error) {
+	cached, err := u.cached()
+	if err != nil {
+		return false, fmt.Errorf("couldn't read cached update check: %v", err)
+	}
+	if cached != nil && time.Since(cached.CheckedAt) < u.frequency() {
+		return cached.Available, nil
+	}
+
+	r, err := u.fetch()
+	if err != nil {
+		// Fall back to the stale cached verdict rather than failing
+		// outright, e.g. if the network is down.
+		if cached != nil {
+			return cached.Available, nil
+		}
+		return false, err
+	}
+
+	if err := u.cache(r); err != nil {
+		return false, fmt.Errorf("couldn't cache update check: %v", err)
+	}
+
+	return r.Available, nil
+}
+
+// fetch hits the GitHub releases API and builds a fresh result.
+func (u *Updater) fetch() (*result, error) {
+	releases, err := u.fetchReleases()
+	if err != nil {
+		return nil, err
+	}
+
+	best, bestVersion, a, ok := bestRelease(releases, u.Prereleases)
+	r := &result{CheckedAt: time.Now()}
+	if !ok {
+		return r, nil
+	}
+
+	if compareVersions(bestVersion, parseVersion(u.CurrentVersion)) <= 0 {
+		return r, nil
+	}
+
+	r.Available = true
+	r.Version = versionString(best.TagName)
+	r.AssetName = a.Name
+	r.DownloadURL = a.BrowserDownloadURL
+	return r, nil
+}
+
+// fetchReleases retrieves the list of releases for u.Repo from the
+// GitHub API.
+func (u *Updater) fetchReleases() ([]release, error) {
+	url := fmt.Sprintf("https://api.github.com/repos/%s/releases", u.Repo)
+
+	resp, err := u.client.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't fetch releases for %s: %v", u.Repo, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GitHub API returned %s for %s", resp.Status, url)
+	}
+
+	var releases []release
+	if err := json.NewDecoder(resp.Body).Decode(&releases); err != nil {
+		return nil, fmt.Errorf("couldn't decode releases for %s: %v", u.Repo, err)
+	}
+
+	return releases, nil
+}
+
+// bestRelease picks the release with the highest semver tag that has a
+// `.alfredworkflow` asset attached, skipping pre-releases unless
+// prereleases is true.
+func bestRelease(releases []release, prereleases bool) (release, []int, asset, bool) {
+	var (
+		best        release
+		bestAsset   asset
+		bestVersion []int
+		found       bool
+	)
+
+	for _, r := range releases {
+		if r.Prerelease && !prereleases {
+			continue
+		}
+		a, ok := r.workflowAsset()
+		if !ok {
+			continue
+		}
+		v := parseVersion(r.TagName)
+		if !found || compareVersions(v, bestVersion) > 0 {
+			best, bestAsset, bestVersion, found = r, a, v, true
+		}
+	}
+
+	return best, bestVersion, bestAsset, found
+}
+
+// parseVersion extracts the dotted numeric version from a tag/version
+// string, e.g. "v1.2.3" -> [1, 2, 3].
+func parseVersion(s string) []int {
+	m := versionPattern.FindString(s)
+	if m == "" {
+		return nil
+	}
+	parts := strings.Split(m, ".")
+	v := make([]int, len(parts))
+	for i, p := range parts {
+		n, _ := strconv.Atoi(p)
+		v[i] = n
+	}
+	return v
+}
+
+// versionString returns the dotted numeric version contained in s,
+// e.g. "v1.2.3" -> "1.2.3".
+func versionString(s string) string {
+	return versionPattern.FindString(s)
+}
+
+// compareVersions returns -1, 0 or 1 if a is less than, equal to or
+// greater than b.
+func compareVersions(a, b []int) int {
+	for i := 0; i < len(a) || i < len(b); i++ {
+		var x, y int
+		if i < len(a) {
+			x = a[i]
+		}
+		if i < len(b) {
+			y = b[i]
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// compareVersions on plain version strings, exported for convenience.
+func CompareVersions(a, b string) int {
+	return compareVersions(parseVersion(a), parseVersion(b))
+}
+
+// Install downloads the update found by the last CheckForUpdate and
+// opens it, so Alfred installs it. CheckForUpdate must have found an
+// available update, or Install returns an error.
+func (u *Updater) Install() error {
+	r, err := u.cached()
+	if err != nil {
+		return fmt.Errorf("couldn't read cached update check: %v", err)
+	}
+	if r == nil || !r.Available {
+		return fmt.Errorf("no update available for %s", u.Repo)
+	}
+
+	path := filepath.Join(u.CacheDir, r.AssetName)
+	if err := u.download(r.DownloadURL, path); err != nil {
+		return fmt.Errorf("couldn't download %s: %v", r.DownloadURL, err)
+	}
+
+	cmd := exec.Command("open", path)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("couldn't open %s: %v", path, err)
+	}
+
+	return nil
+}
+
+// download fetches url and writes it to path.
+func (u *Updater) download(url, path string) error {
+	resp, err := u.client.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("server returned %s", resp.Status)
+	}
+
+	out, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	_, err = io.Copy(out, resp.Body)
+	return err
+}
@@ -0,0 +1,78 @@
+package update
+
+import "testing"
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []int
+	}{
+		{"v1.2.3", []int{1, 2, 3}},
+		{"1.2.3", []int{1, 2, 3}},
+		{"v2.0", []int{2, 0}},
+		{"v1.2.3-beta1", []int{1, 2, 3}},
+		{"not-a-version", nil},
+	}
+
+	for _, tt := range tests {
+		got := parseVersion(tt.in)
+		if !equalInts(got, tt.want) {
+			t.Errorf("parseVersion(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"1.2.3", "1.2.3", 0},
+		{"1.2.4", "1.2.3", 1},
+		{"1.2.3", "1.2.4", -1},
+		{"2.0", "1.9.9", 1},
+		{"1.2", "1.2.0", 0},
+	}
+
+	for _, tt := range tests {
+		got := CompareVersions(tt.a, tt.b)
+		if got != tt.want {
+			t.Errorf("CompareVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestBestRelease(t *testing.T) {
+	releases := []release{
+		{TagName: "v1.0.0", Assets: []asset{{Name: "Workflow.alfredworkflow", BrowserDownloadURL: "v1"}}},
+		{TagName: "v2.0.0", Assets: []asset{{Name: "Workflow.alfredworkflow", BrowserDownloadURL: "v2"}}},
+		{TagName: "v3.0.0-beta", Prerelease: true, Assets: []asset{{Name: "Workflow.alfredworkflow", BrowserDownloadURL: "v3beta"}}},
+		{TagName: "v1.5.0"}, // no .alfredworkflow asset: ignored
+	}
+
+	best, _, a, ok := bestRelease(releases, false)
+	if !ok || best.TagName != "v2.0.0" || a.BrowserDownloadURL != "v2" {
+		t.Errorf("bestRelease(prereleases=false) = %+v, %+v, want v2.0.0/v2", best, a)
+	}
+
+	best, _, a, ok = bestRelease(releases, true)
+	if !ok || best.TagName != "v3.0.0-beta" || a.BrowserDownloadURL != "v3beta" {
+		t.Errorf("bestRelease(prereleases=true) = %+v, %+v, want v3.0.0-beta/v3beta", best, a)
+	}
+
+	if _, _, _, ok := bestRelease(nil, false); ok {
+		t.Error("bestRelease(nil) = ok, want !ok")
+	}
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -1,15 +1,24 @@
 package workflow
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"image/color"
 	"io"
 	"io/ioutil"
 	"log"
 	"os"
+	"os/exec"
 	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"syscall"
 	"time"
 
 	"github.com/mkrautz/plist"
+	"gogs.deanishe.net/deanishe/awgo/update"
 	"gogs.deanishe.net/deanishe/awgo/util"
 )
 
@@ -27,6 +36,7 @@ type Info struct {
 	Description string `plist:"description"`
 	Name        string `plist:"name"`
 	Readme      string `plist:"readme"`
+	Version     string `plist:"version"`
 	Website     string `plist:"webaddress"`
 }
 
@@ -41,6 +51,9 @@ type Workflow struct {
 	// Alfred-specific environmental variables, without the 'alfred_'
 	// prefix. The following variables are present:
 	//
+	//	   debug                   "1" if the workflow is running in
+	//                             Alfred's debugger, "0" otherwise. Use
+	//                             Workflow.Debug() instead.
 	//	   version                 Alfred version number, e.g. "2.7"
 	//     version_build           Alfred build, e.g. "277"
 	//     theme                   ID of current theme, e.g.
@@ -69,6 +82,17 @@ type Workflow struct {
 	// Set this to your workflow's version (used in logging)
 	Version string
 
+	// Updater checks for newer versions of the workflow on GitHub.
+	// It is nil until NewUpdater() is called.
+	Updater *update.Updater
+
+	// MajorVersion selects which generation of Alfred's path layout
+	// BootstrapFromInfoPlist() synthesizes ("workflow_cache" and
+	// "workflow_data" live in different places between Alfred 2 and
+	// Alfred 3+). Defaults to 3. Has no effect once real `alfred_*`
+	// variables are present, i.e. when actually run by Alfred.
+	MajorVersion int
+
 	info       Info
 	infoLoaded bool
 
@@ -77,6 +101,34 @@ type Workflow struct {
 	cacheDir    string
 	dataDir     string
 	workflowDir string
+
+	// bootstrapFailed is set by NewWorkflow() when it is run outside
+	// Alfred and BootstrapFromInfoPlist() couldn't find an info.plist
+	// to synthesize the `alfred_*` environment from, e.g. this
+	// library's own tests. There's no bundle ID to build a cache/log
+	// file path from in that case, so initializeLogging() falls back
+	// to logging to STDERR instead of going through the fatal
+	// GetBundleId()/SendError() path.
+	bootstrapFailed bool
+}
+
+// envKeys lists the Alfred environment variables this library knows
+// about, without their `alfred_` prefix. Shared by loadEnv() (which
+// reads them) and WriteEnvFile() (which dumps them back out).
+var envKeys = []string{
+	"debug",
+	"version",
+	"version_build",
+	"theme",
+	"theme_background",
+	"theme_subtext",
+	"preferences",
+	"preferences_localhash",
+	"workflow_cache",
+	"workflow_data",
+	"workflow_name",
+	"workflow_uid",
+	"workflow_bundleid",
 }
 
 // readInfoPlist loads the data in `info.plist`
@@ -105,26 +157,10 @@ func (wf *Workflow) readInfoPlist() error {
 // loadEnv reads Alfred's variables from the environment.
 func (wf *Workflow) loadEnv() {
 	wf.Env = make(map[string]string)
-	// Variables currently exported by Alfred. These actual names
-	// are prefixed with `alfred_`.
-	keys := []string{
-		"version",
-		"version_build",
-		"theme",
-		"theme_background",
-		"theme_subtext",
-		"preferences",
-		"preferences_localhash",
-		"workflow_cache",
-		"workflow_data",
-		"workflow_name",
-		"workflow_uid",
-		"workflow_bundleid",
-	}
 
 	var val, envkey string
 
-	for _, key := range keys {
+	for _, key := range envKeys {
 		envkey = fmt.Sprintf("alfred_%s", key)
 		val = os.Getenv(envkey)
 		wf.Env[key] = val
@@ -142,9 +178,116 @@ func (wf *Workflow) loadEnv() {
 	}
 }
 
+// BootstrapFromInfoPlist synthesizes the `alfred_*` environment
+// variables a real run by Alfred would have provided, reading the
+// workflow's bundle ID, name and version from `info.plist`. It's
+// normally called automatically by NewWorkflow() when none of the
+// `alfred_workflow_*` variables are already set, letting a workflow be
+// exercised via `go run`/`go test` without a wrapper shell script.
+//
+// Path layout for `workflow_cache` and `workflow_data` is chosen using
+// wf.MajorVersion, which defaults to 3.
+func (wf *Workflow) BootstrapFromInfoPlist() error {
+	// Deliberately doesn't go through wf.readInfoPlist()/
+	// wf.GetWorkflowDir(): those call wf.SendError() (fatal) if the
+	// workflow root can't be found, which is wrong here — not finding
+	// an info.plist outside Alfred (e.g. this library's own tests)
+	// is an expected, recoverable condition, not a reason to crash
+	// during NewWorkflow()/package init().
+	dir, err := util.GetWorkflowRoot()
+	if err != nil {
+		return fmt.Errorf("couldn't locate `info.plist`: %v", err)
+	}
+
+	p := path.Join(dir, "info.plist")
+	buf, err := ioutil.ReadFile(p)
+	if err != nil {
+		return fmt.Errorf("couldn't open `info.plist` (%s): %v", p, err)
+	}
+
+	var info Info
+	if err := plist.Unmarshal(buf, &info); err != nil {
+		return fmt.Errorf("couldn't parse `info.plist` (%s): %v", p, err)
+	}
+
+	if info.BundleId == "" {
+		return errors.New("no bundle ID set in info.plist")
+	}
+
+	wf.workflowDir = dir
+	wf.info = info
+	wf.infoLoaded = true
+	wf.bundleId = info.BundleId
+	wf.name = info.Name
+
+	major := wf.MajorVersion
+	if major == 0 {
+		major = 3
+	}
+
+	var appDir, cacheRoot, dataRoot string
+	if major >= 3 {
+		appDir = "Alfred"
+		cacheRoot = "$HOME/Library/Caches/com.runningwithcrayons.Alfred/Workflow Data"
+		dataRoot = "$HOME/Library/Application Support/Alfred/Workflow Data"
+	} else {
+		appDir = "Alfred 2"
+		cacheRoot = "$HOME/Library/Caches/com.runningwithcrayons.Alfred-2/Workflow Data"
+		dataRoot = "$HOME/Library/Application Support/Alfred 2/Workflow Data"
+	}
+
+	env := map[string]string{
+		"version":               strconv.Itoa(major) + ".0",
+		"version_build":         "0",
+		"theme":                 "alfred.theme.default",
+		"theme_background":      "rgba(255,255,255,1.00)",
+		"theme_subtext":         "0",
+		"preferences":           os.ExpandEnv(fmt.Sprintf("$HOME/Library/Application Support/%s/Alfred.alfredpreferences", appDir)),
+		"preferences_localhash": "",
+		"workflow_cache":        os.ExpandEnv(path.Join(cacheRoot, wf.bundleId)),
+		"workflow_data":         os.ExpandEnv(path.Join(dataRoot, wf.bundleId)),
+		"workflow_name":         wf.name,
+		"workflow_uid":          "user.workflow." + wf.bundleId,
+		"workflow_bundleid":     wf.bundleId,
+	}
+
+	for _, key := range envKeys {
+		val, ok := env[key]
+		if !ok {
+			continue
+		}
+		os.Setenv("alfred_"+key, val)
+	}
+
+	wf.loadEnv()
+
+	return nil
+}
+
+// WriteEnvFile writes the workflow's resolved `alfred_*` environment
+// variables to p as shell `export` statements, so they can be sourced
+// by external scripts or debuggers, e.g. `source FILE`.
+func (wf *Workflow) WriteEnvFile(p string) error {
+	lines := make([]string, 0, len(envKeys))
+	for _, key := range envKeys {
+		lines = append(lines, fmt.Sprintf("export alfred_%s=%q", key, wf.Env[key]))
+	}
+
+	return ioutil.WriteFile(p, []byte(strings.Join(lines, "\n")+"\n"), 0600)
+}
+
 // initializeLogging ensures future log messages are written to
 // workflow's log file.
 func (wf *Workflow) initializeLogging() {
+	if wf.bootstrapFailed {
+		// No info.plist to bootstrap a bundle ID from, so GetLogFile()
+		// (via GetCacheDir()/GetBundleId()) would hit the fatal
+		// SendError() path. Log to STDERR only rather than crash.
+		log.SetOutput(os.Stderr)
+		log.SetFlags(log.Ldate | log.Ltime | log.Lshortfile)
+		return
+	}
+
 	// TODO: Rotate log file
 	file, err := os.OpenFile(wf.GetLogFile(),
 		os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
@@ -153,10 +296,18 @@ func (wf *Workflow) initializeLogging() {
 			wf.GetLogFile(), err))
 	}
 
-	multi := io.MultiWriter(file, os.Stderr)
-	log.SetOutput(multi)
-	log.SetFlags(log.Ldate | log.Ltime | log.Lshortfile)
-	// log.New(multi, "", log.Ldate|log.Ltime|log.Lshortfile)
+	var out io.Writer = file
+	flags := log.Ldate | log.Ltime | log.Lshortfile
+
+	// In debug mode, also mirror log output to STDERR (which Alfred
+	// shows in its debugger) and raise verbosity.
+	if wf.Debug() {
+		out = io.MultiWriter(file, os.Stderr)
+		flags = log.Ldate | log.Ltime | log.Lmicroseconds | log.Llongfile
+	}
+
+	log.SetOutput(out)
+	log.SetFlags(flags)
 }
 
 // GetInfo returns the metadata read from the workflow's info.plist.
@@ -191,6 +342,114 @@ func (wf *Workflow) GetName() string {
 	return wf.name
 }
 
+// GetEnv returns the value of an Alfred environment variable, without
+// its `alfred_` prefix, e.g. "workflow_version". Known variables are
+// read from wf.Env; any other key falls back to
+// os.Getenv("alfred_"+key), so newer Alfred releases' variables are
+// reachable without changes to this library. An empty string is
+// treated as "unset" for the purposes of that fallback.
+func (wf *Workflow) GetEnv(key string) string {
+	if v, ok := wf.Env[key]; ok && v != "" {
+		return v
+	}
+	return os.Getenv("alfred_" + key)
+}
+
+// GetEnvInt returns the value of an Alfred environment variable as an
+// int, or 0 if it is unset or not a valid integer.
+func (wf *Workflow) GetEnvInt(key string) int {
+	v := wf.GetEnv(key)
+	if v == "" {
+		return 0
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+// GetEnvBool returns true if an Alfred environment variable is set to
+// "1".
+func (wf *Workflow) GetEnvBool(key string) bool {
+	return wf.GetEnv(key) == "1"
+}
+
+// Debug returns true if the workflow is running in Alfred's debugger.
+func (wf *Workflow) Debug() bool {
+	return wf.GetEnvBool("debug")
+}
+
+// PreferencesDir returns the path to Alfred's "Alfred.alfredpreferences"
+// bundle.
+func (wf *Workflow) PreferencesDir() string {
+	return wf.GetEnv("preferences")
+}
+
+// PreferencesLocalHash returns the machine-specific hash identifying
+// where this machine's preferences are stored within
+// PreferencesDir()/preferences/local.
+func (wf *Workflow) PreferencesLocalHash() string {
+	return wf.GetEnv("preferences_localhash")
+}
+
+// SubtextMode is the user's preference for when to show an Item's
+// subtitle for alternate actions (triggered by modifier keys).
+type SubtextMode int
+
+// Subtext modes, corresponding to Alfred's `theme_subtext` values.
+const (
+	SubtextAlways    SubtextMode = iota // Always show subtext
+	SubtextAlternate                    // Only show subtext for alternate actions
+	SubtextNever                        // Never show subtext
+)
+
+// Theme describes the user's currently-active Alfred theme.
+type Theme struct {
+	// ID is the theme's identifier, e.g.
+	// "alfred.theme.custom.UUID-UUID-UUID".
+	ID string
+	// Background is the theme's background colour.
+	Background color.RGBA
+	// Subtext is the user's subtext display preference.
+	Subtext SubtextMode
+}
+
+// rgbaPattern matches Alfred's "rgba(255,255,255,1.00)" colour format.
+var rgbaPattern = regexp.MustCompile(`rgba\((\d+),\s*(\d+),\s*(\d+),\s*([\d.]+)\)`)
+
+// parseRGBA parses an Alfred "rgba(r,g,b,a)" string into a color.RGBA.
+func parseRGBA(s string) color.RGBA {
+	m := rgbaPattern.FindStringSubmatch(s)
+	if m == nil {
+		return color.RGBA{}
+	}
+	r, _ := strconv.Atoi(m[1])
+	g, _ := strconv.Atoi(m[2])
+	b, _ := strconv.Atoi(m[3])
+	a, _ := strconv.ParseFloat(m[4], 64)
+	return color.RGBA{R: uint8(r), G: uint8(g), B: uint8(b), A: uint8(a * 255)}
+}
+
+// Theme returns the user's currently-active Alfred theme.
+func (wf *Workflow) Theme() Theme {
+	t := Theme{
+		ID:         wf.GetEnv("theme"),
+		Background: parseRGBA(wf.GetEnv("theme_background")),
+	}
+
+	switch wf.GetEnv("theme_subtext") {
+	case "1":
+		t.Subtext = SubtextAlternate
+	case "2":
+		t.Subtext = SubtextNever
+	default:
+		t.Subtext = SubtextAlways
+	}
+
+	return t
+}
+
 // GetWorkflowDir returns the path to the workflow's root directory.
 func (wf *Workflow) GetWorkflowDir() string {
 	if wf.workflowDir == "" {
@@ -230,6 +489,241 @@ func (wf *Workflow) GetLogFile() string {
 	return path.Join(wf.GetCacheDir(), fmt.Sprintf("%s.log", wf.GetBundleId()))
 }
 
+// cachePath returns the path name is cached under, within GetCacheDir().
+func (wf *Workflow) cachePath(name string) string {
+	return path.Join(wf.GetCacheDir(), name)
+}
+
+// readCache reads the cache file at p, reporting via fresh whether it
+// is within maxAge of its ModTime. maxAge <= 0 means the cache never
+// goes stale.
+func (wf *Workflow) readCache(p string, maxAge time.Duration) (data []byte, fresh bool, err error) {
+	fi, err := os.Stat(p)
+	if err != nil {
+		return nil, false, err
+	}
+	if data, err = ioutil.ReadFile(p); err != nil {
+		return nil, false, err
+	}
+	fresh = maxAge <= 0 || time.Since(fi.ModTime()) < maxAge
+	return data, fresh, nil
+}
+
+// CachedData returns the data cached under name, calling loader to
+// (re-)generate it if the cache is missing or older than maxAge. The
+// result of loader is itself cached under name for next time.
+// maxAge <= 0 means the cache never goes stale.
+func (wf *Workflow) CachedData(name string, loader func() ([]byte, error), maxAge time.Duration) ([]byte, error) {
+	p := wf.cachePath(name)
+
+	data, fresh, err := wf.readCache(p, maxAge)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("couldn't read cache %s : %v", name, err)
+	}
+	if fresh {
+		return data, nil
+	}
+
+	if loader == nil {
+		return data, nil
+	}
+
+	if data, err = loader(); err != nil {
+		return nil, err
+	}
+
+	if err := ioutil.WriteFile(p, data, 0600); err != nil {
+		return nil, fmt.Errorf("couldn't write cache %s : %v", name, err)
+	}
+
+	return data, nil
+}
+
+// CachedJSON populates v by unmarshalling the data returned by
+// CachedData(name, ..., maxAge). loader returns the value to be cached,
+// which is marshalled to JSON before being written to the cache.
+func (wf *Workflow) CachedJSON(name string, v interface{}, loader func() (interface{}, error), maxAge time.Duration) error {
+	wrapped := func() ([]byte, error) {
+		val, err := loader()
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(val)
+	}
+
+	data, err := wf.CachedData(name, wrapped, maxAge)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, v)
+}
+
+// CachedDataAsync returns the data cached under name immediately, even
+// if it's older than maxAge, while kicking off a background refresh
+// (another run of the workflow's own binary, as `$0 --refresh <name>`)
+// if the cache is stale. It's intended for data that's expensive to
+// fetch (e.g. an API response) where showing stale results beats
+// making the user wait, mirroring the `cached_data` /
+// `__workflow_update_status` pattern used by Python's alfred-workflow.
+//
+// If there's no cached data at all yet, loader is called synchronously
+// so the caller still gets something to work with.
+func (wf *Workflow) CachedDataAsync(name string, loader func() ([]byte, error), maxAge time.Duration) ([]byte, error) {
+	p := wf.cachePath(name)
+
+	data, fresh, err := wf.readCache(p, maxAge)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("couldn't read cache %s : %v", name, err)
+	}
+
+	if data == nil {
+		return wf.CachedData(name, loader, maxAge)
+	}
+
+	if !fresh {
+		if err := wf.runBackgroundRefresh(name); err != nil {
+			log.Printf("[cache] couldn't start background refresh of %s : %v", name, err)
+		}
+	}
+
+	return data, nil
+}
+
+// maxRefreshAge bounds how long runBackgroundRefresh() trusts a lock
+// file, regardless of whether its PID still resolves to a live
+// process. Without this, a refresh lock that outlives its process
+// (PID reused by an unrelated process) would wedge CachedDataAsync()
+// into skipping refreshes forever.
+const maxRefreshAge = 10 * time.Minute
+
+// refreshLock records the background refresh process started by
+// runBackgroundRefresh(), so a later call can tell whether a refresh
+// is still in flight.
+type refreshLock struct {
+	Pid       int       `json:"pid"`
+	StartedAt time.Time `json:"started_at"`
+}
+
+// runBackgroundRefresh re-invokes the workflow's own binary as
+// `$0 --refresh <name>` to repopulate a stale CachedDataAsync() entry,
+// skipping the re-invocation if one is already running.
+func (wf *Workflow) runBackgroundRefresh(name string) error {
+	pidPath := wf.cachePath(name + ".pid")
+
+	if lock, ok := readRefreshLock(pidPath); ok {
+		if time.Since(lock.StartedAt) < maxRefreshAge && processIsRunning(lock.Pid) {
+			return nil
+		}
+	}
+
+	cmd := exec.Command(os.Args[0], "--refresh", name)
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	lock := refreshLock{Pid: cmd.Process.Pid, StartedAt: time.Now()}
+	data, err := json.Marshal(lock)
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(pidPath, data, 0600); err != nil {
+		return err
+	}
+
+	// Best-effort: clean up the lock as soon as the refresh finishes,
+	// so its PID can't later be mistaken for a still-running refresh.
+	// If this process (a Script Filter run, typically) exits before
+	// the child does, this goroutine never gets to remove the lock —
+	// maxRefreshAge above is the backstop for that case.
+	go func() {
+		cmd.Wait()
+		os.Remove(pidPath)
+	}()
+
+	return nil
+}
+
+// readRefreshLock reads the lock file written by runBackgroundRefresh.
+func readRefreshLock(p string) (refreshLock, bool) {
+	data, err := ioutil.ReadFile(p)
+	if err != nil {
+		return refreshLock{}, false
+	}
+	var lock refreshLock
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return refreshLock{}, false
+	}
+	return lock, true
+}
+
+// processIsRunning returns true if pid identifies a running process.
+func processIsRunning(pid int) bool {
+	proc, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	return proc.Signal(syscall.Signal(0)) == nil
+}
+
+// NewUpdater creates an Updater that checks repo's GitHub releases for
+// newer `.alfredworkflow` files than currentVersion, and assigns it to
+// wf.Updater. It also returns the Updater, for further configuration,
+// e.g. setting Prereleases or Frequency.
+func (wf *Workflow) NewUpdater(repo, currentVersion string) *update.Updater {
+	u := update.New(repo, currentVersion)
+	u.CacheDir = wf.GetCacheDir()
+	wf.Updater = u
+	return u
+}
+
+// CheckForUpdate checks whether a newer version of the workflow is
+// available on GitHub. wf.NewUpdater() must have been called first.
+func (wf *Workflow) CheckForUpdate() (bool, error) {
+	if wf.Updater == nil {
+		return false, errors.New("no updater configured: call NewUpdater() first")
+	}
+	return wf.Updater.CheckForUpdate()
+}
+
+// UpdateAvailable returns true if a newer version of the workflow is
+// available on GitHub. It is a convenience wrapper around
+// CheckForUpdate() that swallows errors (logging them instead), as
+// it's normally called to decide whether to show an "update available"
+// row in the feedback, where a failed check shouldn't be fatal.
+func (wf *Workflow) UpdateAvailable() bool {
+	if wf.Updater == nil {
+		return false
+	}
+	ok, err := wf.Updater.CheckForUpdate()
+	if err != nil {
+		log.Printf("[update] couldn't check for update : %v", err)
+		return false
+	}
+	return ok
+}
+
+// InstallUpdate downloads and installs the newest available version
+// of the workflow. wf.CheckForUpdate() (or UpdateAvailable()) must
+// have found an update first.
+func (wf *Workflow) InstallUpdate() error {
+	if wf.Updater == nil {
+		return errors.New("no updater configured: call NewUpdater() first")
+	}
+	return wf.Updater.Install()
+}
+
+// NewUpdateAvailableItem adds and returns a feedback Item telling the
+// user a newer version of the workflow is available, for use in
+// workflows that show an "update available" row when UpdateAvailable()
+// returns true (cf. the forklift workflow's update-available.png icon).
+func (wf *Workflow) NewUpdateAvailableItem() *Item {
+	it := wf.NewItem()
+	it.Title = fmt.Sprintf("An update is available for %s", wf.GetName())
+	it.Icon = ICON_INFO
+	return it
+}
+
 // NewItem adds and returns a new feedback Item.
 // See Feedback.NewItem() for more information.
 func (wf *Workflow) NewItem() *Item {
@@ -299,10 +793,32 @@ func (wf *Workflow) SendFeedback() {
 func NewWorkflow() *Workflow {
 	var w Workflow
 	w.loadEnv()
+
+	// Not run by Alfred, e.g. under `go run`/`go test`: synthesize the
+	// environment Alfred would have set from info.plist so the rest of
+	// the library works as if it had been.
+	if !hasAlfredEnv() {
+		if err := w.BootstrapFromInfoPlist(); err != nil {
+			log.Printf("[bootstrap] couldn't load `info.plist` : %v", err)
+			w.bootstrapFailed = true
+		}
+	}
+
 	w.initializeLogging()
 	return &w
 }
 
+// hasAlfredEnv returns true if any `alfred_workflow_*` variable is set,
+// i.e. the process was actually launched by Alfred.
+func hasAlfredEnv() bool {
+	for _, kv := range os.Environ() {
+		if strings.HasPrefix(kv, "alfred_workflow_") {
+			return true
+		}
+	}
+	return false
+}
+
 func init() {
 	defaultWorkflow = NewWorkflow()
 }
@@ -347,6 +863,53 @@ func GetWorkflowDir() string {
 	return defaultWorkflow.GetWorkflowDir()
 }
 
+// WriteEnvFile writes the default Workflow's resolved `alfred_*`
+// environment variables to p as shell `export` statements. See
+// Workflow.WriteEnvFile() for more information.
+func WriteEnvFile(p string) error {
+	return defaultWorkflow.WriteEnvFile(p)
+}
+
+// GetEnv returns the value of an Alfred environment variable. See
+// Workflow.GetEnv() for more information.
+func GetEnv(key string) string {
+	return defaultWorkflow.GetEnv(key)
+}
+
+// GetEnvInt returns the value of an Alfred environment variable as an
+// int. See Workflow.GetEnvInt() for more information.
+func GetEnvInt(key string) int {
+	return defaultWorkflow.GetEnvInt(key)
+}
+
+// GetEnvBool returns true if an Alfred environment variable is set to
+// "1". See Workflow.GetEnvBool() for more information.
+func GetEnvBool(key string) bool {
+	return defaultWorkflow.GetEnvBool(key)
+}
+
+// Debug returns true if the workflow is running in Alfred's debugger.
+func Debug() bool {
+	return defaultWorkflow.Debug()
+}
+
+// PreferencesDir returns the path to Alfred's "Alfred.alfredpreferences"
+// bundle.
+func PreferencesDir() string {
+	return defaultWorkflow.PreferencesDir()
+}
+
+// PreferencesLocalHash returns the machine-specific hash identifying
+// where this machine's preferences are stored.
+func PreferencesLocalHash() string {
+	return defaultWorkflow.PreferencesLocalHash()
+}
+
+// GetTheme returns the user's currently-active Alfred theme.
+func GetTheme() Theme {
+	return defaultWorkflow.Theme()
+}
+
 // NewItem adds and returns a new feedback Item.
 // See Feedback.NewItem() for more information.
 func NewItem() *Item {
@@ -359,6 +922,53 @@ func NewFileItem(path string) *Item {
 	return defaultWorkflow.NewFileItem(path)
 }
 
+// CachedData returns the data cached under name, generating it with
+// loader if necessary. See Workflow.CachedData() for more information.
+func CachedData(name string, loader func() ([]byte, error), maxAge time.Duration) ([]byte, error) {
+	return defaultWorkflow.CachedData(name, loader, maxAge)
+}
+
+// CachedJSON populates v from the JSON cached under name, generating
+// it with loader if necessary. See Workflow.CachedJSON() for more
+// information.
+func CachedJSON(name string, v interface{}, loader func() (interface{}, error), maxAge time.Duration) error {
+	return defaultWorkflow.CachedJSON(name, v, loader, maxAge)
+}
+
+// CachedDataAsync returns the data cached under name immediately,
+// refreshing it in the background if it's stale. See
+// Workflow.CachedDataAsync() for more information.
+func CachedDataAsync(name string, loader func() ([]byte, error), maxAge time.Duration) ([]byte, error) {
+	return defaultWorkflow.CachedDataAsync(name, loader, maxAge)
+}
+
+// NewUpdater creates an Updater and assigns it to the default
+// Workflow's Updater field. See Workflow.NewUpdater() for more
+// information.
+func NewUpdater(repo, currentVersion string) *update.Updater {
+	return defaultWorkflow.NewUpdater(repo, currentVersion)
+}
+
+// CheckForUpdate checks whether a newer version of the workflow is
+// available on GitHub. See Workflow.CheckForUpdate() for more
+// information.
+func CheckForUpdate() (bool, error) {
+	return defaultWorkflow.CheckForUpdate()
+}
+
+// UpdateAvailable returns true if a newer version of the workflow is
+// available on GitHub. See Workflow.UpdateAvailable() for more
+// information.
+func UpdateAvailable() bool {
+	return defaultWorkflow.UpdateAvailable()
+}
+
+// InstallUpdate downloads and installs the newest available version
+// of the workflow. See Workflow.InstallUpdate() for more information.
+func InstallUpdate() error {
+	return defaultWorkflow.InstallUpdate()
+}
+
 // SendError sends an error message to Alfred as XML feedback and
 // terminates the workflow.
 func SendError(err error) {
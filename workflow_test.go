@@ -0,0 +1,29 @@
+package workflow
+
+import (
+	"image/color"
+	"testing"
+)
+
+// TestParseRGBA exercises only the pure colour-parsing helper, but
+// still runs behind package init() (defaultWorkflow = NewWorkflow()),
+// so it depends on that init() not crashing outside Alfred with no
+// info.plist present — see BootstrapFromInfoPlist()/bootstrapFailed.
+func TestParseRGBA(t *testing.T) {
+	tests := []struct {
+		in   string
+		want color.RGBA
+	}{
+		{"rgba(255,255,255,1.00)", color.RGBA{R: 255, G: 255, B: 255, A: 255}},
+		{"rgba(0,0,0,0.00)", color.RGBA{R: 0, G: 0, B: 0, A: 0}},
+		{"rgba(30, 30, 30, 0.50)", color.RGBA{R: 30, G: 30, B: 30, A: 127}},
+		{"not-a-colour", color.RGBA{}},
+	}
+
+	for _, tt := range tests {
+		got := parseRGBA(tt.in)
+		if got != tt.want {
+			t.Errorf("parseRGBA(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}